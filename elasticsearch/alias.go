@@ -0,0 +1,209 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+//CreateAlias points alias at index
+func (cli *Client) CreateAlias(ctx context.Context, alias, index string) (int, error) {
+	return cli.swapAliasActions(ctx, aliasAction{Add: &aliasActionTarget{Index: index, Alias: alias}})
+}
+
+//DeleteAlias removes alias from index
+func (cli *Client) DeleteAlias(ctx context.Context, alias, index string) (int, error) {
+	return cli.swapAliasActions(ctx, aliasAction{Remove: &aliasActionTarget{Index: index, Alias: alias}})
+}
+
+//SwapAlias atomically moves alias from oldIndex to newIndex via a single `_aliases` request,
+//so readers never see a moment where the alias points at neither index
+func (cli *Client) SwapAlias(ctx context.Context, alias, oldIndex, newIndex string) (int, error) {
+	aliasesPath := cli.pickNode(nil) + "/_aliases"
+
+	payload, err := json.Marshal(aliasesRequest{
+		Actions: []aliasAction{
+			{Remove: &aliasActionTarget{Index: oldIndex, Alias: alias}},
+			{Add: &aliasActionTarget{Index: newIndex, Alias: alias}},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	_, status, err := cli.callElastic(ctx, aliasesPath, "POST", payload)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+type aliasActionTarget struct {
+	Index string `json:"index"`
+	Alias string `json:"alias"`
+}
+
+type aliasAction struct {
+	Add    *aliasActionTarget `json:"add,omitempty"`
+	Remove *aliasActionTarget `json:"remove,omitempty"`
+}
+
+type aliasesRequest struct {
+	Actions []aliasAction `json:"actions"`
+}
+
+func (cli *Client) swapAliasActions(ctx context.Context, action aliasAction) (int, error) {
+	aliasesPath := cli.pickNode(nil) + "/_aliases"
+
+	payload, err := json.Marshal(aliasesRequest{Actions: []aliasAction{action}})
+	if err != nil {
+		return 0, err
+	}
+
+	_, status, err := cli.callElastic(ctx, aliasesPath, "POST", payload)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+type indicesForAliasResponse map[string]struct {
+	Aliases map[string]json.RawMessage `json:"aliases"`
+}
+
+//IndicesForAlias returns the names of the indexes currently pointed at by alias
+func (cli *Client) IndicesForAlias(ctx context.Context, alias string) ([]string, error) {
+	aliasPath := cli.pickNode(nil) + "/_alias/" + alias
+
+	body, _, err := cli.callElastic(ctx, aliasPath, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp indicesForAliasResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode alias response from elastic", log.ERROR, log.Error(err))
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(resp))
+	for index := range resp {
+		indices = append(indices, index)
+	}
+	return indices, nil
+}
+
+// TaskID identifies an asynchronous elasticsearch task, e.g. a reindex submitted with
+// wait_for_completion=false.
+type TaskID string
+
+// ReindexOptions controls the body of a `_reindex` request beyond its source and destination.
+type ReindexOptions struct {
+	// Query restricts which documents are copied. A nil Query reindexes everything.
+	Query json.RawMessage
+}
+
+type reindexRequest struct {
+	Source reindexSource `json:"source"`
+	Dest   reindexDest   `json:"dest"`
+}
+
+type reindexSource struct {
+	Index string          `json:"index"`
+	Query json.RawMessage `json:"query,omitempty"`
+}
+
+type reindexDest struct {
+	Index string `json:"index"`
+}
+
+type reindexResponse struct {
+	Task string `json:"task"`
+}
+
+//Reindex submits a `POST /_reindex?wait_for_completion=false` copying documents from source
+//to dest and returns a TaskID that can be polled with TaskStatus or WaitForTask
+func (cli *Client) Reindex(ctx context.Context, source, dest string, opts ReindexOptions) (TaskID, error) {
+	reindexPath := cli.pickNode(nil) + "/_reindex?wait_for_completion=false"
+
+	payload, err := json.Marshal(reindexRequest{
+		Source: reindexSource{Index: source, Query: opts.Query},
+		Dest:   reindexDest{Index: dest},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	body, _, err := cli.callElastic(ctx, reindexPath, "POST", payload)
+	if err != nil {
+		return "", err
+	}
+
+	var resp reindexResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode reindex response from elastic", log.ERROR, log.Error(err))
+		return "", err
+	}
+
+	return TaskID(resp.Task), nil
+}
+
+// TaskStatusResponse is the decoded response body of a call to `_tasks/<id>`.
+type TaskStatusResponse struct {
+	Completed bool            `json:"completed"`
+	Task      json.RawMessage `json:"task"`
+	Error     json.RawMessage `json:"error,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+}
+
+//TaskStatus fetches the current status of a task submitted by Reindex or similar async APIs
+func (cli *Client) TaskStatus(ctx context.Context, id TaskID) (*TaskStatusResponse, error) {
+	taskPath := cli.pickNode(nil) + "/_tasks/" + string(id)
+
+	body, _, err := cli.callElastic(ctx, taskPath, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp TaskStatusResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode task status response from elastic", log.ERROR, log.Error(err))
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+//WaitForTask polls TaskStatus every poll interval until the task completes, honouring ctx
+//cancellation. poll must be positive. If the completed task reports a non-empty Error, that
+//is returned as an error rather than being treated as success.
+func (cli *Client) WaitForTask(ctx context.Context, id TaskID, poll time.Duration) (*TaskStatusResponse, error) {
+	if poll <= 0 {
+		return nil, fmt.Errorf("poll interval must be positive, got %s", poll)
+	}
+
+	ticker := time.NewTicker(poll)
+	defer ticker.Stop()
+
+	for {
+		status, err := cli.TaskStatus(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if status.Completed {
+			if len(status.Error) > 0 {
+				return status, fmt.Errorf("task %s failed: %s", id, status.Error)
+			}
+			return status, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
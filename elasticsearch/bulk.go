@@ -0,0 +1,258 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// BulkItem is a single document to be indexed as part of a bulk request.
+type BulkItem struct {
+	Index    string
+	ID       string
+	Document []byte
+}
+
+// BulkItemResult is the per-item outcome reported back by elasticsearch for a bulk request.
+type BulkItemResult struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// BulkResponse is the decoded response body of a call to the `_bulk` endpoint.
+type BulkResponse struct {
+	Took   int              `json:"took"`
+	Errors bool             `json:"errors"`
+	Items  []BulkItemResult `json:"-"`
+}
+
+type bulkResponseBody struct {
+	Took   int  `json:"took"`
+	Errors bool `json:"errors"`
+	Items  []struct {
+		Index BulkItemResult `json:"index"`
+	} `json:"items"`
+}
+
+//Bulk sends a pre-built NDJSON payload to the `_bulk` endpoint and decodes the response
+func (cli *Client) Bulk(ctx context.Context, ndjson []byte) (BulkResponse, error) {
+	bulkPath := cli.pickNode(nil) + "/_bulk"
+
+	logData := log.Data{"num_bytes": len(ndjson)}
+
+	body, status, err := cli.callElasticWithHeaders(ctx, bulkPath, "POST", ndjson, map[string]string{
+		"Content-Type": "application/x-ndjson",
+	})
+	if err != nil {
+		log.Event(ctx, "bulk request to elastic failed", log.ERROR, log.Error(err), logData)
+		return BulkResponse{}, err
+	}
+
+	var raw bulkResponseBody
+	if err := json.Unmarshal(body, &raw); err != nil {
+		log.Event(ctx, "failed to decode bulk response from elastic", log.ERROR, log.Error(err), log.Data{"status_code": status})
+		return BulkResponse{}, err
+	}
+
+	resp := BulkResponse{
+		Took:   raw.Took,
+		Errors: raw.Errors,
+		Items:  make([]BulkItemResult, len(raw.Items)),
+	}
+	for i, item := range raw.Items {
+		resp.Items[i] = item.Index
+	}
+
+	return resp, nil
+}
+
+//BulkIndex builds an NDJSON payload from items and submits it via Bulk
+func (cli *Client) BulkIndex(ctx context.Context, indexName string, items []BulkItem) (BulkResponse, error) {
+	ndjson, err := buildBulkNDJSON(indexName, items)
+	if err != nil {
+		return BulkResponse{}, err
+	}
+	return cli.Bulk(ctx, ndjson)
+}
+
+func buildBulkNDJSON(indexName string, items []BulkItem) ([]byte, error) {
+	var buf bytes.Buffer
+
+	for _, item := range items {
+		idx := indexName
+		if item.Index != "" {
+			idx = item.Index
+		}
+
+		action := struct {
+			Index struct {
+				Index string `json:"_index"`
+				ID    string `json:"_id,omitempty"`
+			} `json:"index"`
+		}{}
+		action.Index.Index = idx
+		action.Index.ID = item.ID
+
+		actionLine, err := json.Marshal(action)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(actionLine)
+		buf.WriteByte('\n')
+		buf.Write(item.Document)
+		buf.WriteByte('\n')
+	}
+
+	return buf.Bytes(), nil
+}
+
+// BulkIndexerConfig controls when a BulkIndexer flushes its buffered items.
+type BulkIndexerConfig struct {
+	// MaxActions is the number of buffered items that triggers a flush. Zero disables the limit.
+	MaxActions int
+	// MaxBytes is the total size in bytes of the buffered documents that triggers a flush; the
+	// NDJSON action lines are not counted. Zero disables the limit.
+	MaxBytes int
+	// FlushInterval flushes whatever is buffered on a timer, regardless of size. Zero disables the timer.
+	FlushInterval time.Duration
+}
+
+// BulkIndexer buffers BulkItems and flushes them to elasticsearch in batches, either when a
+// threshold in BulkIndexerConfig is reached or when Close is called.
+type BulkIndexer struct {
+	cli    *Client
+	index  string
+	config BulkIndexerConfig
+
+	mu      sync.Mutex
+	buf     []BulkItem
+	bufSize int
+
+	errMu     sync.Mutex
+	flushErrs []error
+
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewBulkIndexer creates a BulkIndexer that batches documents destined for indexName.
+func NewBulkIndexer(cli *Client, indexName string, config BulkIndexerConfig) *BulkIndexer {
+	bi := &BulkIndexer{
+		cli:    cli,
+		index:  indexName,
+		config: config,
+		done:   make(chan struct{}),
+	}
+
+	if config.FlushInterval > 0 {
+		bi.wg.Add(1)
+		go bi.runFlushTimer()
+	}
+
+	return bi
+}
+
+// Add buffers an item, flushing the current batch first if adding it would breach a configured threshold.
+func (bi *BulkIndexer) Add(ctx context.Context, item BulkItem) error {
+	bi.mu.Lock()
+	if bi.config.MaxActions > 0 && len(bi.buf)+1 > bi.config.MaxActions {
+		bi.mu.Unlock()
+		if err := bi.Flush(ctx); err != nil {
+			return err
+		}
+		bi.mu.Lock()
+	}
+	if bi.config.MaxBytes > 0 && bi.bufSize+len(item.Document) > bi.config.MaxBytes {
+		bi.mu.Unlock()
+		if err := bi.Flush(ctx); err != nil {
+			return err
+		}
+		bi.mu.Lock()
+	}
+
+	bi.buf = append(bi.buf, item)
+	bi.bufSize += len(item.Document)
+	bi.mu.Unlock()
+
+	return nil
+}
+
+// Flush dispatches the current batch, if any, to be sent concurrently with any other
+// in-flight batch. It does not block until the send completes - poll Errs() for the outcome,
+// or use Close at shutdown, which does wait for every dispatched batch to finish.
+func (bi *BulkIndexer) Flush(ctx context.Context) error {
+	bi.mu.Lock()
+	if len(bi.buf) == 0 {
+		bi.mu.Unlock()
+		return nil
+	}
+	batch := bi.buf
+	bi.buf = nil
+	bi.bufSize = 0
+	bi.mu.Unlock()
+
+	bi.wg.Add(1)
+	go func() {
+		defer bi.wg.Done()
+		if _, err := bi.cli.BulkIndex(ctx, bi.index, batch); err != nil {
+			bi.errMu.Lock()
+			bi.flushErrs = append(bi.flushErrs, err)
+			bi.errMu.Unlock()
+		}
+	}()
+
+	return nil
+}
+
+// Errs returns every flush error observed since the last call to Errs, clearing them from
+// the indexer's internal buffer.
+func (bi *BulkIndexer) Errs() []error {
+	bi.errMu.Lock()
+	defer bi.errMu.Unlock()
+	errs := bi.flushErrs
+	bi.flushErrs = nil
+	return errs
+}
+
+// Close stops the flush timer, dispatches any remaining buffered items, and waits for that
+// final batch and every other in-flight batch to finish sending before returning. Any errors
+// encountered, including ones from batches dispatched before Close was called, are joined
+// into the returned error.
+func (bi *BulkIndexer) Close(ctx context.Context) error {
+	close(bi.done)
+
+	if err := bi.Flush(ctx); err != nil {
+		return err
+	}
+
+	bi.wg.Wait()
+
+	if errs := bi.Errs(); len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+func (bi *BulkIndexer) runFlushTimer() {
+	defer bi.wg.Done()
+
+	ticker := time.NewTicker(bi.config.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = bi.Flush(context.Background())
+		case <-bi.done:
+			return
+		}
+	}
+}
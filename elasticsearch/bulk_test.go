@@ -0,0 +1,71 @@
+package elasticsearch
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBulkIndexerFlushesOnMaxActions(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"took":1,"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	cli := NewClient(server.URL, false, 0)
+	bi := NewBulkIndexer(cli, "my-index", BulkIndexerConfig{MaxActions: 2})
+
+	ctx := context.Background()
+	for i, id := range []string{"1", "2", "3"} {
+		if err := bi.Add(ctx, BulkItem{ID: id, Document: []byte(`{"a":1}`)}); err != nil {
+			t.Fatalf("Add item %d: %v", i, err)
+		}
+	}
+
+	if err := bi.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// The third Add should have flushed the first two items on crossing MaxActions, and Close
+	// should have flushed the third - two bulk requests in total.
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Fatalf("expected 2 bulk requests, got %d", got)
+	}
+}
+
+func TestBulkIndexerCloseWaitsForInFlightFlush(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"took":1,"errors":false,"items":[]}`))
+	}))
+	defer server.Close()
+
+	cli := NewClient(server.URL, false, 0)
+	bi := NewBulkIndexer(cli, "my-index", BulkIndexerConfig{})
+
+	ctx := context.Background()
+	if err := bi.Add(ctx, BulkItem{ID: "1", Document: []byte(`{"a":1}`)}); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+
+	if err := bi.Close(ctx); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	// Close triggers the final Flush itself, so by the time it returns the request it
+	// dispatched must already have completed - not merely be in flight.
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Fatalf("expected Close to wait for the in-flight flush to complete, got %d requests", got)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	esauth "github.com/ONSdigital/dp-elasticsearch/v2/awsauth"
@@ -31,13 +33,30 @@ type Client struct {
 	serviceName  string
 	signRequests bool
 	indexes      []string
+	retryPolicy  RetryPolicy
+
+	nodePool        *NodePool
+	disableSniffing bool
+
+	versionMu    sync.RWMutex
+	version      string
+	versionMajor int
 }
 
-// NewClient returns a new initialised elasticsearch client with the default dp-net/http client
+// NewClient returns a new initialised elasticsearch client with the default dp-net/http client.
+// maxRetries now configures cli's own RetryPolicy rather than the underlying HTTP client's
+// retry loop, since stacking both would compound backoffs under sustained failures - see
+// NewDefaultRetryPolicyWithMaxAttempts.
 func NewClient(url string, signRequests bool, maxRetries int, indexes ...string) *Client {
 	httpClient := dphttp.NewClient()
-	httpClient.SetMaxRetries(maxRetries)
-	return NewClientWithHTTPClient(url, signRequests, httpClient, indexes...)
+	httpClient.SetMaxRetries(0)
+
+	cli := NewClientWithHTTPClient(url, signRequests, httpClient, indexes...)
+	// maxRetries == 0 must still mean "no retries", matching its historic meaning when it fed
+	// straight into httpClient.SetMaxRetries - so it's handled explicitly rather than only
+	// overriding the NewDefaultRetryPolicy default when maxRetries > 0.
+	cli.retryPolicy = NewDefaultRetryPolicyWithMaxAttempts(maxRetries + 1)
+	return cli
 }
 
 // NewClientWithHTTPClient returns a new initialised elasticsearch client with the provided HTTP client
@@ -55,13 +74,14 @@ func NewClientWithHTTPClientAndOptionalAWSSignage(url, awsRegion, awsService str
 		serviceName:  ServiceName,
 		signRequests: signRequests,
 		indexes:      indexes,
+		retryPolicy:  NewDefaultRetryPolicy(),
 	}
 }
 
 //CreateIndex creates an index in elasticsearch
 func (cli *Client) CreateIndex(ctx context.Context, indexName string, indexSettings []byte) (int, error) {
 
-	indexPath := cli.url + "/" + indexName
+	indexPath := cli.pickNode(nil) + "/" + indexName
 	_, status, err := cli.callElastic(ctx, indexPath, "PUT", indexSettings)
 	if err != nil {
 		return status, err
@@ -72,7 +92,7 @@ func (cli *Client) CreateIndex(ctx context.Context, indexName string, indexSetti
 //DeleteIndex deletes an index in elasticsearch
 func (cli *Client) DeleteIndex(ctx context.Context, indexName string) (int, error) {
 
-	indexPath := cli.url + "/" + indexName
+	indexPath := cli.pickNode(nil) + "/" + indexName
 	_, status, err := cli.callElastic(ctx, indexPath, "DELETE", nil)
 	if err != nil {
 		return status, err
@@ -80,10 +100,16 @@ func (cli *Client) DeleteIndex(ctx context.Context, indexName string) (int, erro
 	return status, nil
 }
 
-//AddDocument adds a JSON document to elasticsearch
+//AddDocument adds a JSON document to elasticsearch. documentType is only honoured against
+//elasticsearch 5.x and 6.x clusters; against 7.x and above it must be empty, since mapping
+//types were removed - see ErrDocumentTypeNotSupported.
 func (cli *Client) AddDocument(ctx context.Context, indexName, documentType, documentID string, document []byte) (int, error) {
 
-	documentPath := cli.url + "/" + indexName + "/" + documentType + "/" + documentID
+	documentPath, err := cli.documentPath(ctx, indexName, documentType, documentID)
+	if err != nil {
+		return 0, err
+	}
+
 	_, status, err := cli.callElastic(ctx, documentPath, "PUT", document)
 	if err != nil {
 		return status, err
@@ -94,12 +120,71 @@ func (cli *Client) AddDocument(ctx context.Context, indexName, documentType, doc
 
 // CallElastic builds a request to elasticsearch based on the method, path and payload
 func (cli *Client) callElastic(ctx context.Context, path, method string, payload []byte) ([]byte, int, error) {
+	return cli.callElasticWithHeaders(ctx, path, method, payload, nil)
+}
+
+// callElasticWithHeaders builds a request to elasticsearch as callElastic does, but allows the
+// caller to override the default "application/json" Content-type, e.g. for NDJSON bulk requests.
+// Transient failures (429/502/503/504 and network errors) are retried according to
+// cli.retryPolicy, checking for context cancellation between attempts.
+func (cli *Client) callElasticWithHeaders(ctx context.Context, path, method string, payload []byte, headers map[string]string) ([]byte, int, error) {
+	var backoff time.Duration
+	excludedNodes := map[string]bool{}
+
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		body, status, retryAfter, err := cli.doElasticRequest(ctx, path, method, payload, headers)
+		if err == nil {
+			return body, status, nil
+		}
+
+		if !cli.retryPolicy.ShouldRetry(status, err, attempt) {
+			return body, status, err
+		}
+
+		// A connection-level failure (status 0) against a NodePool-backed client is retried
+		// on a different node, rather than hammering the one that just failed.
+		if cli.nodePool != nil && status == 0 {
+			if failedHost, hostErr := hostOf(path); hostErr == nil {
+				cli.nodePool.MarkUnhealthy(failedHost)
+				excludedNodes[failedHost] = true
+			}
+			if nextNode := cli.nodePool.Next(excludedNodes); nextNode != "" {
+				if rebuilt, rebuildErr := rebuildURL(path, nextNode); rebuildErr == nil {
+					path = rebuilt
+				}
+			}
+		}
+
+		backoff = cli.retryPolicy.Backoff(attempt, backoff)
+		if retryAfter > 0 {
+			backoff = retryAfter
+		}
+
+		log.Event(ctx, "retrying call to elastic after transient failure", log.WARN, log.Error(err), log.Data{"url": path, "method": method, "attempt": attempt, "backoff": backoff.String()})
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, status, ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
+
+// doElasticRequest performs a single attempt at calling elasticsearch, returning the response
+// body, status code, any Retry-After duration the response specified, and an error.
+func (cli *Client) doElasticRequest(ctx context.Context, path, method string, payload []byte, headers map[string]string) ([]byte, int, time.Duration, error) {
 	logData := log.Data{"url": path, "method": method}
 
 	URL, err := url.Parse(path)
 	if err != nil {
 		log.Event(ctx, "failed to create url for elastic call", log.ERROR, log.Error(err), logData)
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 	path = URL.String()
 	logData["url"] = path
@@ -118,37 +203,59 @@ func (cli *Client) callElastic(ctx context.Context, path, method string, payload
 	// check req, above, didn't error
 	if err != nil {
 		log.Event(ctx, "failed to create request for call to elastic", log.ERROR, log.Error(err), logData)
-		return nil, 0, err
+		return nil, 0, 0, err
+	}
+
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
 
 	if cli.signRequests {
 		signer := esauth.NewSigner(cli.awsSDKSigner, cli.awsService, cli.awsRegion)
 		if err = signer.Sign(req, bodyReader, time.Now()); err != nil {
-			return nil, 0, err
+			return nil, 0, 0, err
 		}
 	}
 
 	resp, err := cli.httpCli.Do(ctx, req)
 	if err != nil {
 		log.Event(ctx, "failed to call elastic", log.ERROR, log.Error(err), logData)
-		return nil, 0, err
+		return nil, 0, 0, err
 	}
 	defer resp.Body.Close()
 
 	logData["http_code"] = resp.StatusCode
+	retryAfter := parseRetryAfter(resp.Header.Get("Retry-After"))
 
 	jsonBody, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		log.Event(ctx, "failed to read response body from call to elastic", log.ERROR, log.Error(err), logData)
-		return nil, resp.StatusCode, err
+		return nil, resp.StatusCode, retryAfter, err
 	}
 	logData["json_body"] = string(jsonBody)
 	logData["status_code"] = resp.StatusCode
 
 	if resp.StatusCode < http.StatusOK || resp.StatusCode >= 300 {
+		if esErr, ok := parseESError(resp.StatusCode, jsonBody); ok {
+			log.Event(ctx, "failed", log.ERROR, log.Error(esErr), logData)
+			return jsonBody, resp.StatusCode, retryAfter, esErr
+		}
 		log.Event(ctx, "failed", log.ERROR, log.Error(ErrorUnexpectedStatusCode), logData)
-		return nil, resp.StatusCode, ErrorUnexpectedStatusCode
+		return jsonBody, resp.StatusCode, retryAfter, ErrorUnexpectedStatusCode
 	}
 
-	return jsonBody, resp.StatusCode, nil
+	return jsonBody, resp.StatusCode, retryAfter, nil
+}
+
+// parseRetryAfter parses a Retry-After header given in seconds, returning 0 if it is absent
+// or not a plain integer (the HTTP-date form is not supported).
+func parseRetryAfter(header string) time.Duration {
+	if header == "" {
+		return 0
+	}
+	seconds, err := strconv.Atoi(header)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
 }
@@ -0,0 +1,86 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrorUnexpectedStatusCode is returned when elasticsearch responds with a non-2xx status
+// whose body could not be parsed as a structured elasticsearch error - see ESError for the
+// common case where it can be.
+var ErrorUnexpectedStatusCode = errors.New("unexpected status code from elastic search")
+
+// ESErrorCause is one entry of the "root_cause" array elasticsearch includes in an error body.
+type ESErrorCause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+	Index  string `json:"index,omitempty"`
+}
+
+// ESError is a structured elasticsearch error, decoded from a response body of the form
+// `{"error":{"type":"...","reason":"...","root_cause":[...]}}`.
+type ESError struct {
+	StatusCode int
+	Type       string
+	Reason     string
+	Index      string
+	RootCause  []ESErrorCause
+	Raw        []byte
+}
+
+func (e *ESError) Error() string {
+	if e.Reason != "" {
+		return e.Type + ": " + e.Reason
+	}
+	return e.Type
+}
+
+type esErrorBody struct {
+	Error struct {
+		Type      string         `json:"type"`
+		Reason    string         `json:"reason"`
+		Index     string         `json:"index,omitempty"`
+		RootCause []ESErrorCause `json:"root_cause,omitempty"`
+	} `json:"error"`
+}
+
+// parseESError attempts to decode body as a structured elasticsearch error. It returns nil,
+// false if body doesn't look like one, so the caller can fall back to ErrorUnexpectedStatusCode.
+func parseESError(statusCode int, body []byte) (*ESError, bool) {
+	var parsed esErrorBody
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return nil, false
+	}
+	if parsed.Error.Type == "" {
+		return nil, false
+	}
+
+	return &ESError{
+		StatusCode: statusCode,
+		Type:       parsed.Error.Type,
+		Reason:     parsed.Error.Reason,
+		Index:      parsed.Error.Index,
+		RootCause:  parsed.Error.RootCause,
+		Raw:        body,
+	}, true
+}
+
+// IsNotFound reports whether err is an *ESError for a missing index or document.
+func IsNotFound(err error) bool {
+	esErr, ok := err.(*ESError)
+	return ok && (esErr.StatusCode == 404 || esErr.Type == "index_not_found_exception")
+}
+
+// IsConflict reports whether err is an *ESError with status 409 or a
+// version_conflict_engine_exception, i.e. a document version conflict.
+func IsConflict(err error) bool {
+	esErr, ok := err.(*ESError)
+	return ok && (esErr.StatusCode == 409 || esErr.Type == "version_conflict_engine_exception")
+}
+
+// IsRejected reports whether err is an *ESError caused by a full thread pool (the condition
+// RetryPolicy treats as transient via its 429 handling).
+func IsRejected(err error) bool {
+	esErr, ok := err.(*ESError)
+	return ok && esErr.Type == "es_rejected_execution_exception"
+}
@@ -0,0 +1,297 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	dphttp "github.com/ONSdigital/dp-net/http"
+	"github.com/ONSdigital/log.go/log"
+)
+
+// NodePool round-robins requests across a set of elasticsearch node URLs. It health-checks
+// the nodes in the background and, unless sniffing is disabled, periodically refreshes its
+// membership from the cluster's own `_nodes/http` endpoint.
+type NodePool struct {
+	httpCli dphttp.Clienter
+
+	mu      sync.Mutex
+	nodes   []string
+	healthy map[string]bool
+	next    int
+
+	sniff               bool
+	sniffInterval       time.Duration
+	healthCheckInterval time.Duration
+
+	closeOnce sync.Once
+	stop      chan struct{}
+}
+
+// NewNodePool creates a NodePool seeded with the given node URLs. Sniffing is enabled by
+// default; call StartBackgroundChecks to begin health-checking and, if enabled, sniffing.
+func NewNodePool(httpCli dphttp.Clienter, seedURLs []string) *NodePool {
+	healthy := make(map[string]bool, len(seedURLs))
+	for _, u := range seedURLs {
+		healthy[u] = true
+	}
+
+	return &NodePool{
+		httpCli:             httpCli,
+		nodes:               append([]string(nil), seedURLs...),
+		healthy:             healthy,
+		sniff:               true,
+		sniffInterval:       30 * time.Second,
+		healthCheckInterval: 10 * time.Second,
+		stop:                make(chan struct{}),
+	}
+}
+
+// Next returns the next node in rotation that is both healthy and not present in exclude. If
+// no node qualifies, it falls back to the next node regardless of health and of exclude, so a
+// caller always has something to try.
+func (p *NodePool) Next(exclude map[string]bool) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.nodes) == 0 {
+		return ""
+	}
+
+	for i := 0; i < len(p.nodes); i++ {
+		node := p.nodes[p.next%len(p.nodes)]
+		p.next++
+		if p.healthy[node] && !exclude[node] {
+			return node
+		}
+	}
+
+	node := p.nodes[p.next%len(p.nodes)]
+	p.next++
+	return node
+}
+
+// MarkUnhealthy excludes node from rotation until the next health check marks it healthy again.
+func (p *NodePool) MarkUnhealthy(node string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.healthy[node] = false
+}
+
+// StartBackgroundChecks launches the health-check loop, and the sniff loop if enabled. It
+// returns immediately; call Close to stop both.
+func (p *NodePool) StartBackgroundChecks(ctx context.Context) {
+	go p.healthCheckLoop(ctx)
+	if p.sniff {
+		go p.sniffLoop(ctx)
+	}
+}
+
+// Close stops the background health-check and sniff loops. Safe to call more than once.
+func (p *NodePool) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+}
+
+func (p *NodePool) healthCheckLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.checkHealth(ctx)
+		case <-p.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *NodePool) checkHealth(ctx context.Context) {
+	p.mu.Lock()
+	nodes := append([]string(nil), p.nodes...)
+	p.mu.Unlock()
+
+	for _, node := range nodes {
+		req, err := http.NewRequest("GET", node, nil)
+		if err != nil {
+			continue
+		}
+
+		resp, err := p.httpCli.Do(ctx, req)
+		healthy := err == nil && resp != nil && resp.StatusCode < 300
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		p.mu.Lock()
+		p.healthy[node] = healthy
+		p.mu.Unlock()
+	}
+}
+
+type nodesHTTPResponse struct {
+	Nodes map[string]struct {
+		HTTP struct {
+			PublishAddress string `json:"publish_address"`
+		} `json:"http"`
+	} `json:"nodes"`
+}
+
+func (p *NodePool) sniffLoop(ctx context.Context) {
+	ticker := time.NewTicker(p.sniffInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			p.sniffOnce(ctx)
+		case <-p.stop:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// sniffOnce queries `_nodes/http` on any currently known node and replaces the pool's
+// membership with the addresses it reports.
+func (p *NodePool) sniffOnce(ctx context.Context) {
+	seed := p.Next(nil)
+	if seed == "" {
+		return
+	}
+
+	req, err := http.NewRequest("GET", seed+"/_nodes/http", nil)
+	if err != nil {
+		return
+	}
+
+	resp, err := p.httpCli.Do(ctx, req)
+	if err != nil {
+		log.Event(ctx, "failed to sniff elasticsearch nodes", log.WARN, log.Error(err), log.Data{"seed": seed})
+		return
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return
+	}
+
+	var parsed nodesHTTPResponse
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return
+	}
+
+	discovered := make([]string, 0, len(parsed.Nodes))
+	for _, n := range parsed.Nodes {
+		if n.HTTP.PublishAddress == "" {
+			continue
+		}
+		discovered = append(discovered, "http://"+n.HTTP.PublishAddress)
+	}
+	if len(discovered) == 0 {
+		return
+	}
+
+	p.mu.Lock()
+	p.nodes = discovered
+	for _, node := range discovered {
+		if _, known := p.healthy[node]; !known {
+			p.healthy[node] = true
+		}
+	}
+	p.mu.Unlock()
+}
+
+// pickNode returns the node a request should be sent to: the next healthy node from
+// cli.nodePool if one was configured via NewClientWithNodes, or cli.url otherwise.
+func (cli *Client) pickNode(exclude map[string]bool) string {
+	if cli.nodePool != nil {
+		return cli.nodePool.Next(exclude)
+	}
+	return cli.url
+}
+
+// hostOf returns the "scheme://host" portion of a full request URL.
+func hostOf(fullURL string) (string, error) {
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return "", err
+	}
+	return u.Scheme + "://" + u.Host, nil
+}
+
+// rebuildURL re-targets fullURL at newBase, keeping its path and query unchanged. Used to
+// retry a request against a different node after a connection failure.
+func rebuildURL(fullURL, newBase string) (string, error) {
+	u, err := url.Parse(fullURL)
+	if err != nil {
+		return "", err
+	}
+	base, err := url.Parse(newBase)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	return u.String(), nil
+}
+
+// NewClientWithNodes returns a Client that spreads requests across multiple elasticsearch
+// nodes rather than a single endpoint, sniffing the cluster for further members unless
+// DisableSniffing is passed. Disable sniffing for environments - e.g. AWS-managed ES behind
+// a load balancer - where individual node addresses aren't reachable from outside the cluster.
+func NewClientWithNodes(urls []string, opts ...Option) (*Client, error) {
+	if len(urls) == 0 {
+		return nil, errors.New("NewClientWithNodes requires at least one node url")
+	}
+
+	httpClient := dphttp.NewClient()
+
+	cli := &Client{
+		awsRegion:   defaultRegion,
+		awsService:  defaultService,
+		httpCli:     httpClient,
+		url:         urls[0],
+		serviceName: ServiceName,
+		retryPolicy: NewDefaultRetryPolicy(),
+	}
+
+	for _, opt := range opts {
+		opt(cli)
+	}
+
+	pool := NewNodePool(httpClient, urls)
+	pool.sniff = !cli.disableSniffing
+	cli.nodePool = pool
+
+	pool.StartBackgroundChecks(context.Background())
+
+	return cli, nil
+}
+
+// Close stops the background health-check and sniff loops started by NewClientWithNodes. It
+// is a no-op on a Client that wasn't created with NewClientWithNodes.
+func (cli *Client) Close() {
+	if cli.nodePool != nil {
+		cli.nodePool.Close()
+	}
+}
+
+// DisableSniffing turns off periodic `_nodes/http` discovery on a Client created with
+// NewClientWithNodes. The pool still round-robins and health-checks the originally supplied urls.
+func DisableSniffing() Option {
+	return func(cli *Client) {
+		cli.disableSniffing = true
+	}
+}
@@ -0,0 +1,25 @@
+package elasticsearch
+
+// Option configures optional Client behaviour, applied by constructors that accept it.
+type Option func(cli *Client)
+
+// WithIndexes records the index names this Client is expected to manage, for use with
+// constructors such as NewClientWithOptions and NewClientWithNodes that take Options instead
+// of a trailing indexes ...string argument.
+func WithIndexes(indexes ...string) Option {
+	return func(cli *Client) {
+		cli.indexes = indexes
+	}
+}
+
+// NewClientWithOptions returns a Client built the same way as NewClient, with the given
+// Options - e.g. WithRetryPolicy or WithIndexes - applied afterwards.
+func NewClientWithOptions(url string, signRequests bool, maxRetries int, opts ...Option) *Client {
+	cli := NewClient(url, signRequests, maxRetries)
+
+	for _, opt := range opts {
+		opt(cli)
+	}
+
+	return cli
+}
@@ -0,0 +1,94 @@
+package elasticsearch
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy decides whether a failed call to elasticsearch should be retried, and how long
+// to wait before the next attempt.
+type RetryPolicy interface {
+	// ShouldRetry is consulted after an attempt fails. statusCode is 0 if the request never
+	// got a response (e.g. a connection error), in which case err is non-nil. attempt is the
+	// number of attempts made so far, starting at 1.
+	ShouldRetry(statusCode int, err error, attempt int) bool
+	// Backoff returns how long to wait before the next attempt, given the previous backoff
+	// used (0 on the first retry).
+	Backoff(attempt int, previous time.Duration) time.Duration
+}
+
+// WithRetryPolicy overrides the default RetryPolicy used by callElastic.
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(cli *Client) {
+		cli.retryPolicy = policy
+	}
+}
+
+// defaultRetryPolicy retries transient elasticsearch conditions - 429 (bulk/search thread
+// pool rejection), 502/503/504 and network errors - using exponential backoff with
+// decorrelated jitter, capped at maxAttempts.
+type defaultRetryPolicy struct {
+	base        time.Duration
+	cap         time.Duration
+	maxAttempts int
+}
+
+// NewDefaultRetryPolicy returns the RetryPolicy used by NewClientWithHTTPClient and
+// NewClientWithNodes unless overridden with WithRetryPolicy: up to 5 attempts, base 100ms,
+// capped at 30s. NewClient instead sets the attempt count from its maxRetries parameter.
+func NewDefaultRetryPolicy() RetryPolicy {
+	return NewDefaultRetryPolicyWithMaxAttempts(5)
+}
+
+// NewDefaultRetryPolicyWithMaxAttempts is NewDefaultRetryPolicy with the attempt count
+// overridden - used by NewClient to honour its maxRetries parameter through cli's own
+// RetryPolicy now that the underlying HTTP client's blind retry is disabled.
+func NewDefaultRetryPolicyWithMaxAttempts(maxAttempts int) RetryPolicy {
+	return &defaultRetryPolicy{
+		base:        100 * time.Millisecond,
+		cap:         30 * time.Second,
+		maxAttempts: maxAttempts,
+	}
+}
+
+func (p *defaultRetryPolicy) ShouldRetry(statusCode int, err error, attempt int) bool {
+	if attempt >= p.maxAttempts {
+		return false
+	}
+
+	if statusCode == 0 {
+		// err is non-nil whenever statusCode is 0 - a connection-level failure, which is
+		// always worth a retry.
+		return err != nil
+	}
+
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+// Backoff implements decorrelated jitter, as described in the AWS Architecture Blog post
+// "Exponential Backoff And Jitter": sleep = min(cap, random_between(base, previous*3)).
+func (p *defaultRetryPolicy) Backoff(attempt int, previous time.Duration) time.Duration {
+	if previous <= 0 {
+		previous = p.base
+	}
+
+	upper := previous * 3
+	if upper > p.cap {
+		upper = p.cap
+	}
+	if upper <= p.base {
+		return p.base
+	}
+
+	jittered := p.base + time.Duration(rand.Int63n(int64(upper-p.base)))
+	if jittered > p.cap {
+		return p.cap
+	}
+	return jittered
+}
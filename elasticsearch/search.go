@@ -0,0 +1,283 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// SearchOptions controls the query-string parameters of a Search request.
+type SearchOptions struct {
+	From int
+	Size int
+	// Scroll, if non-zero, requests a scroll context be kept alive for the given duration,
+	// e.g. "1m". Prefer Client.Scroll for paging through large result sets.
+	Scroll string
+}
+
+// Hit is a single search result.
+type Hit struct {
+	Index  string          `json:"_index"`
+	ID     string          `json:"_id"`
+	Score  float64         `json:"_score"`
+	Source json.RawMessage `json:"_source"`
+}
+
+// HitsTotal is the "hits.total" field of a search response. Elasticsearch 7.x renders it as
+// an object (`{"value":N,"relation":"eq"}`), but 5.x/6.x, and 7.x with
+// `rest_total_hits_as_int=true`, render it as a plain number - UnmarshalJSON accepts both.
+type HitsTotal struct {
+	Value int
+	// Relation is "eq" if Value is exact, "gte" if it's a lower bound (7.x only, when the
+	// query set track_total_hits below the true count); empty when decoded from a plain number.
+	Relation string
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting either a plain number or a
+// {"value","relation"} object - see HitsTotal.
+func (t *HitsTotal) UnmarshalJSON(data []byte) error {
+	var asInt int
+	if err := json.Unmarshal(data, &asInt); err == nil {
+		t.Value = asInt
+		return nil
+	}
+
+	var asObject struct {
+		Value    int    `json:"value"`
+		Relation string `json:"relation"`
+	}
+	if err := json.Unmarshal(data, &asObject); err != nil {
+		return err
+	}
+	t.Value = asObject.Value
+	t.Relation = asObject.Relation
+	return nil
+}
+
+// Hits is the "hits" section of a search response.
+type Hits struct {
+	Total    HitsTotal `json:"total"`
+	MaxScore float64   `json:"max_score"`
+	Hits     []Hit     `json:"hits"`
+}
+
+// SearchResponse is the decoded response body of a call to `_search`.
+type SearchResponse struct {
+	Took         int                        `json:"took"`
+	TimedOut     bool                       `json:"timed_out"`
+	ScrollID     string                     `json:"_scroll_id,omitempty"`
+	Hits         Hits                       `json:"hits"`
+	Aggregations map[string]json.RawMessage `json:"aggregations,omitempty"`
+}
+
+// GetResponse is the decoded response body of a call to `GET /index/_doc/id`.
+type GetResponse struct {
+	Index   string          `json:"_index"`
+	ID      string          `json:"_id"`
+	Version int             `json:"_version"`
+	Found   bool            `json:"found"`
+	Source  json.RawMessage `json:"_source"`
+}
+
+//Search runs a query against the given index and decodes the response into a SearchResponse
+func (cli *Client) Search(ctx context.Context, index string, query []byte, opts SearchOptions) (*SearchResponse, error) {
+	searchPath := cli.pickNode(nil) + "/" + index + "/_search" + searchQueryString(opts)
+
+	body, _, err := cli.callElastic(ctx, searchPath, "POST", query)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode search response from elastic", log.ERROR, log.Error(err))
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+func searchQueryString(opts SearchOptions) string {
+	qs := ""
+	sep := "?"
+
+	if opts.From > 0 {
+		qs += sep + "from=" + strconv.Itoa(opts.From)
+		sep = "&"
+	}
+	if opts.Size > 0 {
+		qs += sep + "size=" + strconv.Itoa(opts.Size)
+		sep = "&"
+	}
+	if opts.Scroll != "" {
+		qs += sep + "scroll=" + opts.Scroll
+	}
+
+	return qs
+}
+
+//GetDocument fetches a single document by id
+func (cli *Client) GetDocument(ctx context.Context, index, id string) (*GetResponse, error) {
+	documentPath, err := cli.documentPath(ctx, index, "", id)
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := cli.callElastic(ctx, documentPath, "GET", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp GetResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode get response from elastic", log.ERROR, log.Error(err))
+		return nil, err
+	}
+
+	return &resp, nil
+}
+
+//UpdateDocument applies a partial update (a `{"doc": {...}}` body) to an existing document
+func (cli *Client) UpdateDocument(ctx context.Context, index, id string, partial []byte) (int, error) {
+	updatePath, err := cli.updateDocumentPath(ctx, index, id)
+	if err != nil {
+		return 0, err
+	}
+
+	_, status, err := cli.callElastic(ctx, updatePath, "POST", partial)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+//DeleteDocument deletes a single document by id
+func (cli *Client) DeleteDocument(ctx context.Context, index, id string) (int, error) {
+	documentPath, err := cli.documentPath(ctx, index, "", id)
+	if err != nil {
+		return 0, err
+	}
+
+	_, status, err := cli.callElastic(ctx, documentPath, "DELETE", nil)
+	if err != nil {
+		return status, err
+	}
+	return status, nil
+}
+
+// ScrollIterator pages through the results of a Scroll search, fetching the next batch of
+// hits from elasticsearch when Next finds the current batch exhausted.
+type ScrollIterator struct {
+	cli       *Client
+	ctx       context.Context
+	keepAlive string
+	scrollID  string
+	current   *SearchResponse
+	pos       int
+	done      bool
+}
+
+//Scroll starts a scroll search against index and returns an iterator over its pages of hits
+func (cli *Client) Scroll(ctx context.Context, index string, query []byte, keepAlive time.Duration) (*ScrollIterator, error) {
+	scroll := scrollKeepAlive(keepAlive)
+
+	resp, err := cli.Search(ctx, index, query, SearchOptions{Scroll: scroll})
+	if err != nil {
+		return nil, err
+	}
+
+	return &ScrollIterator{
+		cli:       cli,
+		ctx:       ctx,
+		keepAlive: scroll,
+		scrollID:  resp.ScrollID,
+		current:   resp,
+	}, nil
+}
+
+// scrollKeepAlive formats keepAlive as a plain elasticsearch TimeValue - a single integer
+// followed by a unit, e.g. "60s". Duration.String() produces compound strings like "1m0s",
+// which elasticsearch's TimeValue parser rejects.
+func scrollKeepAlive(keepAlive time.Duration) string {
+	return strconv.Itoa(int(keepAlive.Seconds())) + "s"
+}
+
+// Next reports whether another hit is available, fetching the next page from elasticsearch
+// when the current one is exhausted. It does not move past a hit itself - Hit does - so
+// calling Next again without Hit reports the same hit. It returns false once there are no
+// more hits.
+func (it *ScrollIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	if it.current != nil && it.pos < len(it.current.Hits.Hits) {
+		return true
+	}
+
+	if it.current != nil && len(it.current.Hits.Hits) == 0 {
+		it.done = true
+		return false
+	}
+
+	resp, err := it.cli.nextScrollPage(it.ctx, it.scrollID, it.keepAlive)
+	if err != nil || len(resp.Hits.Hits) == 0 {
+		it.done = true
+		return false
+	}
+
+	it.current = resp
+	it.scrollID = resp.ScrollID
+	it.pos = 0
+	return true
+}
+
+// Hit returns the hit the iterator currently points to and moves past it. Call it once
+// after each successful Next.
+func (it *ScrollIterator) Hit() Hit {
+	hit := it.current.Hits.Hits[it.pos]
+	it.pos++
+	return hit
+}
+
+// Close releases the scroll context held open on the elasticsearch cluster.
+func (it *ScrollIterator) Close(ctx context.Context) error {
+	if it.scrollID == "" {
+		return nil
+	}
+	scrollPath := it.cli.pickNode(nil) + "/_search/scroll"
+	body, err := json.Marshal(map[string]string{"scroll_id": it.scrollID})
+	if err != nil {
+		return err
+	}
+	_, _, err = it.cli.callElastic(ctx, scrollPath, "DELETE", body)
+	return err
+}
+
+func (cli *Client) nextScrollPage(ctx context.Context, scrollID, keepAlive string) (*SearchResponse, error) {
+	scrollPath := cli.pickNode(nil) + "/_search/scroll"
+
+	payload, err := json.Marshal(map[string]string{
+		"scroll":    keepAlive,
+		"scroll_id": scrollID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	body, _, err := cli.callElastic(ctx, scrollPath, "POST", payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp SearchResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode scroll response from elastic", log.ERROR, log.Error(err))
+		return nil, err
+	}
+
+	return &resp, nil
+}
@@ -0,0 +1,39 @@
+package elasticsearch
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestHitsTotalUnmarshalsBothShapes(t *testing.T) {
+	cases := []struct {
+		name     string
+		body     string
+		expected HitsTotal
+	}{
+		{
+			name:     "7.x object form",
+			body:     `{"total":{"value":42,"relation":"eq"}}`,
+			expected: HitsTotal{Value: 42, Relation: "eq"},
+		},
+		{
+			name:     "5.x/6.x plain number form",
+			body:     `{"total":42}`,
+			expected: HitsTotal{Value: 42},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var decoded struct {
+				Total HitsTotal `json:"total"`
+			}
+			if err := json.Unmarshal([]byte(c.body), &decoded); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if decoded.Total != c.expected {
+				t.Fatalf("got %+v, want %+v", decoded.Total, c.expected)
+			}
+		})
+	}
+}
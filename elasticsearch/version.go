@@ -0,0 +1,140 @@
+package elasticsearch
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/log.go/log"
+)
+
+// ErrDocumentTypeNotSupported is returned when a caller supplies a non-empty documentType
+// against a cluster running elasticsearch 7.x or above, where mapping types were removed.
+var ErrDocumentTypeNotSupported = errors.New("documentType is not supported by elasticsearch 7.x and above")
+
+// pingResponse is the subset of the `GET /` response body that we care about.
+type pingResponse struct {
+	Version struct {
+		Number string `json:"number"`
+	} `json:"version"`
+}
+
+// Ping queries the elasticsearch root endpoint, caches the cluster's major version on the
+// Client and returns the full version number reported, e.g. "7.10.2".
+func (cli *Client) Ping(ctx context.Context) (string, error) {
+	body, _, err := cli.callElastic(ctx, cli.pickNode(nil), "GET", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var resp pingResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		log.Event(ctx, "failed to decode ping response from elastic", log.ERROR, log.Error(err))
+		return "", err
+	}
+
+	major, err := majorVersion(resp.Version.Number)
+	if err != nil {
+		log.Event(ctx, "failed to parse elasticsearch version", log.ERROR, log.Error(err), log.Data{"version": resp.Version.Number})
+		return "", err
+	}
+
+	cli.versionMu.Lock()
+	cli.version = resp.Version.Number
+	cli.versionMajor = major
+	cli.versionMu.Unlock()
+
+	if major < 7 {
+		log.Event(ctx, "connected to a deprecated elasticsearch version, consider upgrading to 7.x or above", log.WARN, log.Data{"version": resp.Version.Number})
+	}
+
+	return resp.Version.Number, nil
+}
+
+// Version returns the full version string of the cluster as last detected by Ping, or "" if
+// Ping has not yet been called.
+func (cli *Client) Version() string {
+	cli.versionMu.RLock()
+	defer cli.versionMu.RUnlock()
+	return cli.version
+}
+
+// versionMajorOrDetect returns the cached cluster major version, calling Ping to detect it if necessary.
+func (cli *Client) versionMajorOrDetect(ctx context.Context) (int, error) {
+	cli.versionMu.RLock()
+	major := cli.versionMajor
+	cli.versionMu.RUnlock()
+
+	if major != 0 {
+		return major, nil
+	}
+
+	if _, err := cli.Ping(ctx); err != nil {
+		return 0, err
+	}
+
+	cli.versionMu.RLock()
+	defer cli.versionMu.RUnlock()
+	return cli.versionMajor, nil
+}
+
+func majorVersion(number string) (int, error) {
+	parts := strings.SplitN(number, ".", 2)
+	if len(parts) == 0 || parts[0] == "" {
+		return 0, errors.New("empty version number")
+	}
+	return strconv.Atoi(parts[0])
+}
+
+// documentPath builds the version-appropriate path for a single document, rejecting a
+// non-empty documentType against 7.x+ clusters and defaulting to "_doc" for them.
+func (cli *Client) documentPath(ctx context.Context, indexName, documentType, documentID string) (string, error) {
+	major, err := cli.versionMajorOrDetect(ctx)
+	if err != nil {
+		if documentType == "" {
+			// The caller is relying on us to pick the right path for their cluster - most
+			// likely 7.x+, since that's the only version where documentType is legitimately
+			// left empty. Surfacing the detection failure beats guessing and building a
+			// malformed "index//id" path.
+			return "", err
+		}
+		// An explicit documentType means the caller already knows they're on a pre-7.x
+		// cluster, so the historic 5.x/6.x path can still be built without a successful Ping.
+		major = 0
+	}
+
+	switch {
+	case major >= 7:
+		if documentType != "" {
+			return "", ErrDocumentTypeNotSupported
+		}
+		return cli.pickNode(nil) + "/" + indexName + "/_doc/" + documentID, nil
+	default:
+		// Pre-7.x clusters require a mapping type in the path. "_doc" is accepted by every
+		// version we support here (introduced as the recommended single-type name in 6.x, and
+		// a valid - if unconventional - type name on 5.x), so default to it rather than
+		// building a malformed "index//id" path when the caller (e.g. GetDocument,
+		// DeleteDocument) has no documentType to supply.
+		if documentType == "" {
+			documentType = "_doc"
+		}
+		return cli.pickNode(nil) + "/" + indexName + "/" + documentType + "/" + documentID, nil
+	}
+}
+
+// updateDocumentPath builds the version-appropriate path for a partial update, mirroring
+// documentPath's version handling - the `_update` endpoint moved from after the document id
+// to before it when mapping types were removed in 7.x.
+func (cli *Client) updateDocumentPath(ctx context.Context, indexName, documentID string) (string, error) {
+	major, err := cli.versionMajorOrDetect(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	if major >= 7 {
+		return cli.pickNode(nil) + "/" + indexName + "/_update/" + documentID, nil
+	}
+	return cli.pickNode(nil) + "/" + indexName + "/_doc/" + documentID + "/_update", nil
+}